@@ -0,0 +1,73 @@
+package main
+
+import "fmt"
+
+// CodeGen is the interface each architecture backend implements. A loop's
+// id is the index of its OpLoopBegin in the optimized []Op slice, and is
+// passed to both EmitLoopBegin and the matching EmitLoopEnd so a backend
+// can derive a pair of unique labels from a single id.
+type CodeGen interface {
+	EmitPrologue()
+	EmitEpilogue()
+	EmitTape(size int)
+
+	EmitAdd(n, offset int)
+	EmitMove(n int)
+	EmitSet(n, offset int)
+	EmitPut(offset int)
+	EmitGet(offset int)
+	EmitLoopBegin(id int)
+	EmitLoopEnd(id int)
+	EmitScanRight(step int)
+	EmitScanLeft(step int)
+	EmitMulAdd(offset, factor int)
+
+	// EmitLoc records the source position of the next instruction for
+	// backends that support -g; backends without debug support may no-op.
+	EmitLoc(line, col int)
+
+	// Output returns the assembled text after all Emit* calls are done.
+	Output() string
+}
+
+const tapeSize = 30000
+
+// Generate drives a CodeGen over an optimized op stream, handling the
+// common bookkeeping (prologue/epilogue/tape, debug locations, which ops
+// map to which Emit* call) so each backend only needs to know how to
+// render individual instructions.
+func Generate(ops []Op, gen CodeGen, opts EmitOptions) string {
+	gen.EmitPrologue()
+	for i, op := range ops {
+		if opts.Debug {
+			gen.EmitLoc(op.Line, op.Col)
+		}
+		switch op.Kind {
+		case OpMove:
+			gen.EmitMove(op.N)
+		case OpAdd:
+			gen.EmitAdd(op.N, op.Offset)
+		case OpSet:
+			gen.EmitSet(op.N, op.Offset)
+		case OpPut:
+			gen.EmitPut(op.Offset)
+		case OpGet:
+			gen.EmitGet(op.Offset)
+		case OpLoopBegin:
+			gen.EmitLoopBegin(i)
+		case OpLoopEnd:
+			gen.EmitLoopEnd(op.Target)
+		case OpScanRight:
+			gen.EmitScanRight(op.N)
+		case OpScanLeft:
+			gen.EmitScanLeft(op.N)
+		case OpMulAdd:
+			gen.EmitMulAdd(op.Offset, op.N)
+		default:
+			panic(fmt.Sprintf("codegen: unhandled op %v", op))
+		}
+	}
+	gen.EmitEpilogue()
+	gen.EmitTape(tapeSize)
+	return gen.Output()
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+// DWARF v4 tag/attribute/form/opcode constants, limited to the handful
+// emitVariableDebugInfo actually uses. The Go debug/dwarf package (or the
+// DWARF v4 spec) has the full set.
+const (
+	dwTagArrayType    = 0x01
+	dwTagPointerType  = 0x0f
+	dwTagCompileUnit  = 0x11
+	dwTagSubrangeType = 0x21
+	dwTagBaseType     = 0x24
+	dwTagSubprogram   = 0x2e
+	dwTagVariable     = 0x34
+
+	dwChildrenNo  = 0
+	dwChildrenYes = 1
+
+	dwAtLocation   = 0x02
+	dwAtName       = 0x03
+	dwAtByteSize   = 0x0b
+	dwAtStmtList   = 0x10
+	dwAtLowPC      = 0x11
+	dwAtHighPC     = 0x12
+	dwAtCompDir    = 0x1b
+	dwAtUpperBound = 0x2f
+	dwAtProducer   = 0x25
+	dwAtEncoding   = 0x3e
+	dwAtType       = 0x49
+
+	dwFormAddr    = 0x01
+	dwFormData1   = 0x0b
+	dwFormData4   = 0x06
+	dwFormData8   = 0x07
+	dwFormString  = 0x08
+	dwFormRef4    = 0x13
+	dwFormExprloc = 0x18
+
+	dwAteUnsignedChar = 0x08
+
+	dwOpAddr  = 0x03
+	dwOpReg12 = 0x50 + 12 // DW_OP_reg12: the value lives in the r12 register
+)
+
+// emitVariableDebugInfo writes a hand-rolled .debug_abbrev/.debug_info pair
+// describing one compile unit: a "main" subprogram holding a register-located
+// "tape_ptr" variable (DW_OP_reg12, typed as a pointer to unsigned char) and
+// a top-level "tape" variable (typed as an array of tapeSize unsigned chars,
+// located at the tape symbol's address). That's enough for `p *tape_ptr` or
+// `p tape` to work under gdb.
+//
+// gas's own -g handling still synthesizes .debug_line from the .file/.loc
+// directives a CodeGen emits elsewhere; writing the compile unit ourselves
+// here (instead of leaving it to gas) is what lets us attach these variable
+// DIEs, and gas detects the existing .debug_info/.debug_abbrev and skips its
+// own compile-unit generation.
+func emitVariableDebugInfo(buf *bytes.Buffer, srcFile string, tapeSize int) {
+	producer := "brainfuck-go"
+	compDir, err := os.Getwd()
+	if err != nil {
+		compDir = "."
+	}
+
+	// Abbreviation codes, arbitrary but must match the .uleb128 written
+	// before each DIE below.
+	const (
+		abbrevBaseType    = 1
+		abbrevPointerType = 2
+		abbrevArrayType   = 3
+		abbrevSubrange    = 4
+		abbrevSubprogram  = 5
+		abbrevVariable    = 6
+		abbrevCompileUnit = 7
+	)
+
+	buf.WriteString("\t.section .debug_abbrev\n")
+	decl := func(code, tag, children int, attrs ...int) {
+		fmt.Fprintf(buf, "\t.uleb128 %d\n", code)
+		fmt.Fprintf(buf, "\t.uleb128 0x%x\n", tag)
+		fmt.Fprintf(buf, "\t.byte %d\n", children)
+		for i := 0; i < len(attrs); i += 2 {
+			fmt.Fprintf(buf, "\t.uleb128 0x%x\n", attrs[i])
+			fmt.Fprintf(buf, "\t.uleb128 0x%x\n", attrs[i+1])
+		}
+		buf.WriteString("\t.byte 0\n\t.byte 0\n")
+	}
+	decl(abbrevBaseType, dwTagBaseType, dwChildrenNo,
+		dwAtName, dwFormString, dwAtEncoding, dwFormData1, dwAtByteSize, dwFormData1)
+	decl(abbrevPointerType, dwTagPointerType, dwChildrenNo,
+		dwAtByteSize, dwFormData1, dwAtType, dwFormRef4)
+	decl(abbrevArrayType, dwTagArrayType, dwChildrenYes,
+		dwAtType, dwFormRef4)
+	decl(abbrevSubrange, dwTagSubrangeType, dwChildrenNo,
+		dwAtUpperBound, dwFormData4)
+	decl(abbrevSubprogram, dwTagSubprogram, dwChildrenYes,
+		dwAtName, dwFormString, dwAtLowPC, dwFormAddr, dwAtHighPC, dwFormData8)
+	decl(abbrevVariable, dwTagVariable, dwChildrenNo,
+		dwAtName, dwFormString, dwAtType, dwFormRef4, dwAtLocation, dwFormExprloc)
+	decl(abbrevCompileUnit, dwTagCompileUnit, dwChildrenYes,
+		dwAtProducer, dwFormString, dwAtName, dwFormString, dwAtCompDir, dwFormString,
+		dwAtLowPC, dwFormAddr, dwAtHighPC, dwFormData8, dwAtStmtList, dwFormData4)
+	buf.WriteString("\t.byte 0\n")
+
+	// .debug_info: one CU, address_size 8. DW_FORM_ref4 offsets below are
+	// measured from the first byte of the unit_length field (offset 0 of
+	// this CU), so every DIE's offset is tracked by hand as it's laid out.
+	const cuHeaderSize = 4 + 2 + 4 + 1 // unit_length + version + abbrev_offset + address_size
+	cuAttrsSize := 1 /*abbrev code*/ +
+		len(producer) + 1 + len(srcFile) + 1 + len(compDir) + 1 + /*strings*/
+		8 /*low_pc*/ + 8 /*high_pc*/ + 4 /*stmt_list*/
+	baseTypeOff := cuHeaderSize + cuAttrsSize
+	baseTypeSize := 1 + len("unsigned char") + 1 + 1 + 1
+	pointerTypeOff := baseTypeOff + baseTypeSize
+	pointerTypeSize := 1 + 1 + 4
+	arrayTypeOff := pointerTypeOff + pointerTypeSize
+
+	buf.WriteString("\t.section .debug_info\n")
+	buf.WriteString("\t.long .Ldwarf_cu_end - .Ldwarf_cu_version\n")
+	buf.WriteString(".Ldwarf_cu_version:\n")
+	buf.WriteString("\t.2byte 4\n")
+	buf.WriteString("\t.long 0\n")
+	buf.WriteString("\t.byte 8\n")
+
+	fmt.Fprintf(buf, "\t.uleb128 %d\n", abbrevCompileUnit)
+	fmt.Fprintf(buf, "\t.asciz %q\n", producer)
+	fmt.Fprintf(buf, "\t.asciz %q\n", srcFile)
+	fmt.Fprintf(buf, "\t.asciz %q\n", compDir)
+	buf.WriteString("\t.quad main\n")
+	buf.WriteString("\t.quad .Ldwarf_func_end - main\n")
+	buf.WriteString("\t.long 0\n") // DW_AT_stmt_list: gas's line program, at .debug_line offset 0
+
+	fmt.Fprintf(buf, "\t.uleb128 %d\n", abbrevBaseType)
+	buf.WriteString("\t.asciz \"unsigned char\"\n")
+	fmt.Fprintf(buf, "\t.byte 0x%x\n", dwAteUnsignedChar)
+	buf.WriteString("\t.byte 1\n")
+
+	fmt.Fprintf(buf, "\t.uleb128 %d\n", abbrevPointerType)
+	buf.WriteString("\t.byte 8\n")
+	fmt.Fprintf(buf, "\t.long %d\n", baseTypeOff)
+
+	fmt.Fprintf(buf, "\t.uleb128 %d\n", abbrevArrayType)
+	fmt.Fprintf(buf, "\t.long %d\n", baseTypeOff)
+	fmt.Fprintf(buf, "\t.uleb128 %d\n", abbrevSubrange)
+	fmt.Fprintf(buf, "\t.long %d\n", tapeSize-1)
+	buf.WriteString("\t.byte 0\n") // end array_type's children
+
+	fmt.Fprintf(buf, "\t.uleb128 %d\n", abbrevSubprogram)
+	buf.WriteString("\t.asciz \"main\"\n")
+	buf.WriteString("\t.quad main\n")
+	buf.WriteString("\t.quad .Ldwarf_func_end - main\n")
+	fmt.Fprintf(buf, "\t.uleb128 %d\n", abbrevVariable)
+	buf.WriteString("\t.asciz \"tape_ptr\"\n")
+	fmt.Fprintf(buf, "\t.long %d\n", pointerTypeOff)
+	buf.WriteString("\t.uleb128 1\n") // exprloc length: one opcode byte
+	fmt.Fprintf(buf, "\t.byte 0x%x\n", dwOpReg12)
+	buf.WriteString("\t.byte 0\n") // end subprogram's children
+
+	fmt.Fprintf(buf, "\t.uleb128 %d\n", abbrevVariable)
+	buf.WriteString("\t.asciz \"tape\"\n")
+	fmt.Fprintf(buf, "\t.long %d\n", arrayTypeOff)
+	buf.WriteString("\t.uleb128 9\n") // exprloc length: DW_OP_addr + 8-byte address
+	fmt.Fprintf(buf, "\t.byte 0x%x\n", dwOpAddr)
+	buf.WriteString("\t.quad tape\n")
+
+	buf.WriteString("\t.byte 0\n") // end compile_unit's children
+	buf.WriteString(".Ldwarf_cu_end:\n")
+}
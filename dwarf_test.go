@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestDebugBuildHasLineInfo compiles a tiny program with -g and checks that
+// the produced binary carries a DWARF line table mapping back to the .bf
+// source, without requiring gdb itself to be installed.
+func TestDebugBuildHasLineInfo(t *testing.T) {
+	if _, err := exec.LookPath("gcc"); err != nil {
+		t.Skip("gcc not available")
+	}
+	if _, err := exec.LookPath("readelf"); err != nil {
+		t.Skip("readelf not available")
+	}
+
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "prog.bf")
+	if err := os.WriteFile(srcPath, []byte("++.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ops, err := Lex([]byte("++.\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops = Optimize(ops)
+	asmText := EmitAMD64(ops, EmitOptions{Debug: true, SrcFile: srcPath})
+
+	if !strings.Contains(asmText, ".loc 1") {
+		t.Fatalf("expected .loc directives in debug build, got:\n%s", asmText)
+	}
+
+	asmPath := filepath.Join(dir, "prog.s")
+	if err := os.WriteFile(asmPath, []byte(asmText), 0644); err != nil {
+		t.Fatal(err)
+	}
+	exePath := filepath.Join(dir, "prog")
+	cmd := exec.Command("gcc", "-no-pie", "-g", asmPath, "-o", exePath)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("gcc failed: %v\n%s", err, out)
+	}
+
+	out, err = exec.Command("readelf", "-S", exePath).CombinedOutput()
+	if err != nil {
+		t.Fatalf("readelf failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(string(out), ".debug_line") {
+		t.Fatalf("expected a .debug_line section, got:\n%s", out)
+	}
+
+	if _, err := exec.LookPath("gdb"); err != nil {
+		t.Skip("gdb not available; skipping source-line assertion")
+	}
+	out, err = exec.Command("gdb", "-batch", "-ex", "info line *main", exePath).CombinedOutput()
+	if err != nil {
+		t.Fatalf("gdb failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(string(out), "prog.bf") {
+		t.Fatalf("expected gdb to resolve the source file, got:\n%s", out)
+	}
+}
+
+// TestDebugBuildHasTapeVariables compiles a tiny program with -g and checks
+// that the DWARF info carries the synthetic "tape_ptr" (r12) and "tape"
+// variable DIEs, so `p *tape_ptr` and `p tape` resolve under gdb.
+func TestDebugBuildHasTapeVariables(t *testing.T) {
+	if _, err := exec.LookPath("gcc"); err != nil {
+		t.Skip("gcc not available")
+	}
+	if _, err := exec.LookPath("readelf"); err != nil {
+		t.Skip("readelf not available")
+	}
+
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "prog.bf")
+	if err := os.WriteFile(srcPath, []byte("++.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ops, err := Lex([]byte("++.\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops = Optimize(ops)
+	asmText := EmitAMD64(ops, EmitOptions{Debug: true, SrcFile: srcPath})
+
+	asmPath := filepath.Join(dir, "prog.s")
+	if err := os.WriteFile(asmPath, []byte(asmText), 0644); err != nil {
+		t.Fatal(err)
+	}
+	exePath := filepath.Join(dir, "prog")
+	cmd := exec.Command("gcc", "-no-pie", "-g", asmPath, "-o", exePath)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("gcc failed: %v\n%s", err, out)
+	}
+
+	out, err = exec.Command("readelf", "--debug-dump=info", exePath).CombinedOutput()
+	if err != nil {
+		t.Fatalf("readelf failed: %v\n%s", err, out)
+	}
+	info := string(out)
+	for _, want := range []string{"tape_ptr", "DW_OP_reg12", "DW_TAG_variable", "tape"} {
+		if !strings.Contains(info, want) {
+			t.Fatalf("expected %q in DWARF info, got:\n%s", want, info)
+		}
+	}
+	if strings.Contains(info, "Unknown") {
+		t.Fatalf("readelf could not decode part of the DWARF info:\n%s", info)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"os"
+)
+
+// loadAddr is the virtual address the single PT_LOAD segment is mapped at.
+// It matches the default base gcc/ld use for static, non-PIE executables,
+// so output from -no-gcc looks unremarkable under objdump/gdb.
+const loadAddr = 0x400000
+
+// elfHeaderSize is the on-disk size of an ELF64 header plus the one program
+// header this mode needs (EmitOptions.Debug and multiple segments aren't
+// supported here).
+const elfHeaderSize = 64 + 56
+
+// EmitELF assembles ops into a standalone, statically linked ELF64
+// executable: a single PT_LOAD segment holding the generated code (using
+// inline read/write/exit syscalls, no libc) followed by a zero-filled
+// region for the 30000-byte tape, which the kernel's loader bss-fills for
+// free since p_memsz exceeds p_filesz.
+func EmitELF(ops []Op) []byte {
+	a, tapePtrOffset := assembleX86Body(ops)
+	a.emit(0xB8) // mov eax, 60 (exit)
+	a.emit32(60)
+	a.emit(0x31, 0xFF) // xor edi, edi
+	a.emit(0x0F, 0x05) // syscall
+
+	filesz := uint64(elfHeaderSize + len(a.code))
+	tapeAddr := loadAddr + filesz
+	a.patchImm64(tapePtrOffset, tapeAddr)
+
+	entry := uint64(loadAddr + elfHeaderSize)
+	memsz := filesz + tapeSize
+
+	var ident [elf.EI_NIDENT]byte
+	copy(ident[:], elf.ELFMAG)
+	ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+	ident[elf.EI_OSABI] = byte(elf.ELFOSABI_NONE)
+
+	eh := elf.Header64{
+		Ident:     ident,
+		Type:      uint16(elf.ET_EXEC),
+		Machine:   uint16(elf.EM_X86_64),
+		Version:   uint32(elf.EV_CURRENT),
+		Entry:     entry,
+		Phoff:     64,
+		Ehsize:    64,
+		Phentsize: 56,
+		Phnum:     1,
+	}
+	ph := elf.Prog64{
+		Type:   uint32(elf.PT_LOAD),
+		Flags:  uint32(elf.PF_R | elf.PF_W | elf.PF_X),
+		Off:    0,
+		Vaddr:  loadAddr,
+		Paddr:  loadAddr,
+		Filesz: filesz,
+		Memsz:  memsz,
+		Align:  0x1000,
+	}
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, eh)
+	binary.Write(&buf, binary.LittleEndian, ph)
+	buf.Write(a.code)
+	return buf.Bytes()
+}
+
+// WriteELFExecutable assembles ops and writes the resulting standalone
+// executable to path with the executable bit set.
+func WriteELFExecutable(ops []Op, path string) error {
+	if err := os.WriteFile(path, EmitELF(ops), 0755); err != nil {
+		return fmt.Errorf("write elf executable: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"debug/elf"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestELFHeaderFields(t *testing.T) {
+	ops := Optimize(mustLex(t, canonicalProgram))
+	f, err := elf.NewFile(bytes.NewReader(EmitELF(ops)))
+	if err != nil {
+		t.Fatalf("parse generated ELF: %v", err)
+	}
+	if f.Type != elf.ET_EXEC {
+		t.Errorf("Type = %v, want ET_EXEC", f.Type)
+	}
+	if f.Machine != elf.EM_X86_64 {
+		t.Errorf("Machine = %v, want EM_X86_64", f.Machine)
+	}
+	if f.Entry != loadAddr+elfHeaderSize {
+		t.Errorf("Entry = %#x, want %#x", f.Entry, loadAddr+elfHeaderSize)
+	}
+}
+
+// TestELFExecutableRuns writes the canonical program out with -no-gcc and
+// checks the resulting binary runs standalone (no libc, no dynamic linker)
+// and produces the same output as the gcc-assembled path.
+func TestELFExecutableRuns(t *testing.T) {
+	ops := Optimize(mustLex(t, canonicalProgram))
+	dir := t.TempDir()
+	exePath := filepath.Join(dir, "prog")
+	if err := WriteELFExecutable(ops, exePath); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(exePath)
+	cmd.Stdin = nil
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("run generated executable: %v", err)
+	}
+	if string(out) != "\x00" {
+		t.Errorf("output = %q, want %q", out, "\x00")
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// ARM64Gen emits AArch64 assembly for gcc under the AAPCS64 calling
+// convention, using x19 (callee-saved) as the tape pointer. It implements
+// CodeGen.
+type ARM64Gen struct {
+	opts  EmitOptions
+	buf   bytes.Buffer
+	scans int
+}
+
+// NewARM64Gen returns a CodeGen targeting AArch64.
+func NewARM64Gen(opts EmitOptions) *ARM64Gen {
+	return &ARM64Gen{opts: opts}
+}
+
+// addr returns the register holding the address of the current cell plus
+// offset. For offset 0 that's just x19; otherwise the address is
+// materialized into the scratch register x10 so callers don't have to
+// juggle restoring x19 afterwards.
+func (g *ARM64Gen) addr(offset int) string {
+	if offset == 0 {
+		return "x19"
+	}
+	fmt.Fprintf(&g.buf, "\tmov x9, #%d\n", offset)
+	g.buf.WriteString("\tadd x10, x19, x9\n")
+	return "x10"
+}
+
+func (g *ARM64Gen) EmitPrologue() {
+	g.buf.WriteString("\t.arch armv8-a\n")
+	g.buf.WriteString("\t.section .text\n")
+	g.buf.WriteString("\t.global main\n")
+	g.buf.WriteString("\t.type main, %function\n")
+	g.buf.WriteString("main:\n")
+	g.buf.WriteString("\tstp x29, x30, [sp, -16]!\n")
+	g.buf.WriteString("\tmov x29, sp\n")
+	g.buf.WriteString("\tadrp x19, tape\n")
+	g.buf.WriteString("\tadd x19, x19, :lo12:tape\n")
+}
+
+func (g *ARM64Gen) EmitEpilogue() {
+	g.buf.WriteString("\tmov w0, 0\n")
+	g.buf.WriteString("\tldp x29, x30, [sp], 16\n")
+	g.buf.WriteString("\tret\n")
+}
+
+func (g *ARM64Gen) EmitTape(size int) {
+	g.buf.WriteString("\t.section .bss\n")
+	g.buf.WriteString("\t.align 3\n")
+	g.buf.WriteString("\t.type tape, %object\n")
+	fmt.Fprintf(&g.buf, "\t.size tape, %d\n", size)
+	g.buf.WriteString("tape:\n")
+	fmt.Fprintf(&g.buf, "\t.zero %d\n", size)
+}
+
+func (g *ARM64Gen) EmitMove(n int) {
+	if n == 0 {
+		return
+	}
+	fmt.Fprintf(&g.buf, "\tmov x9, #%d\n", n)
+	g.buf.WriteString("\tadd x19, x19, x9\n")
+}
+
+func (g *ARM64Gen) EmitAdd(n, offset int) {
+	imm := n & 0xff
+	if imm == 0 {
+		return
+	}
+	reg := g.addr(offset)
+	g.buf.WriteString("\tldrb w0, [" + reg + "]\n")
+	fmt.Fprintf(&g.buf, "\tadd w0, w0, #%d\n", imm)
+	g.buf.WriteString("\tstrb w0, [" + reg + "]\n")
+}
+
+func (g *ARM64Gen) EmitSet(n, offset int) {
+	reg := g.addr(offset)
+	fmt.Fprintf(&g.buf, "\tmov w0, #%d\n", n&0xff)
+	g.buf.WriteString("\tstrb w0, [" + reg + "]\n")
+}
+
+func (g *ARM64Gen) EmitPut(offset int) {
+	reg := g.addr(offset)
+	g.buf.WriteString("\tldrb w0, [" + reg + "]\n")
+	g.buf.WriteString("\tbl putchar\n")
+}
+
+func (g *ARM64Gen) EmitGet(offset int) {
+	g.buf.WriteString("\tbl getchar\n")
+	reg := g.addr(offset)
+	g.buf.WriteString("\tstrb w0, [" + reg + "]\n")
+}
+
+func (g *ARM64Gen) EmitLoopBegin(id int) {
+	begin, end := loopLabels(id)
+	fmt.Fprintf(&g.buf, "%s:\n", begin)
+	g.buf.WriteString("\tldrb w0, [x19]\n")
+	fmt.Fprintf(&g.buf, "\tcbz w0, %s\n", end)
+}
+
+func (g *ARM64Gen) EmitLoopEnd(id int) {
+	begin, end := loopLabels(id)
+	g.buf.WriteString("\tldrb w0, [x19]\n")
+	fmt.Fprintf(&g.buf, "\tcbnz w0, %s\n", begin)
+	fmt.Fprintf(&g.buf, "%s:\n", end)
+}
+
+func (g *ARM64Gen) emitScan(step int, sub bool) {
+	g.scans++
+	begin := fmt.Sprintf(".Lscan_%d", g.scans)
+	end := begin + "_done"
+	fmt.Fprintf(&g.buf, "%s:\n", begin)
+	g.buf.WriteString("\tldrb w0, [x19]\n")
+	fmt.Fprintf(&g.buf, "\tcbz w0, %s\n", end)
+	fmt.Fprintf(&g.buf, "\tmov x9, #%d\n", step)
+	if sub {
+		g.buf.WriteString("\tsub x19, x19, x9\n")
+	} else {
+		g.buf.WriteString("\tadd x19, x19, x9\n")
+	}
+	fmt.Fprintf(&g.buf, "\tb %s\n", begin)
+	fmt.Fprintf(&g.buf, "%s:\n", end)
+}
+
+func (g *ARM64Gen) EmitScanRight(step int) { g.emitScan(step, false) }
+func (g *ARM64Gen) EmitScanLeft(step int)  { g.emitScan(step, true) }
+
+func (g *ARM64Gen) EmitMulAdd(offset, factor int) {
+	g.buf.WriteString("\tldrb w0, [x19]\n")
+	reg := g.addr(offset)
+	g.buf.WriteString("\tldrb w1, [" + reg + "]\n")
+	switch factor {
+	case 1:
+		g.buf.WriteString("\tadd w1, w1, w0\n")
+	case -1:
+		g.buf.WriteString("\tsub w1, w1, w0\n")
+	default:
+		fmt.Fprintf(&g.buf, "\tmov w2, #%d\n", factor)
+		g.buf.WriteString("\tmul w0, w0, w2\n")
+		g.buf.WriteString("\tadd w1, w1, w0\n")
+	}
+	g.buf.WriteString("\tstrb w1, [" + reg + "]\n")
+}
+
+// EmitLoc is a no-op: the ARM64 backend does not support -g yet.
+func (g *ARM64Gen) EmitLoc(line, col int) {}
+
+func (g *ARM64Gen) Output() string { return g.buf.String() }
+
+// EmitARM64 turns an optimized IR program into AArch64 assembly.
+func EmitARM64(ops []Op, opts EmitOptions) string {
+	return Generate(ops, NewARM64Gen(opts), opts)
+}
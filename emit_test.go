@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// canonicalProgram is the fixture used for golden-file backend tests: it
+// exercises a mul loop, a clear loop, a scan loop, and plain I/O.
+const canonicalProgram = "++++++++[->++++<]>[->+>+<<]>>[-<<+>>]<<[>]>.,[-]"
+
+func TestGoldenAMD64(t *testing.T) {
+	ops := Optimize(mustLex(t, canonicalProgram))
+	got := EmitAMD64(ops, EmitOptions{})
+	want := readGolden(t, "canonical_amd64.s")
+	if got != want {
+		t.Errorf("amd64 output does not match golden file; got:\n%s", got)
+	}
+}
+
+func TestGoldenARM64(t *testing.T) {
+	ops := Optimize(mustLex(t, canonicalProgram))
+	got := EmitARM64(ops, EmitOptions{})
+	want := readGolden(t, "canonical_arm64.s")
+	if got != want {
+		t.Errorf("arm64 output does not match golden file; got:\n%s", got)
+	}
+}
+
+// TestARM64RunsUnderQEMU cross-assembles the canonical program for arm64 and
+// runs it under qemu-aarch64, when both an aarch64 cross gcc and qemu-aarch64
+// are available. It's skipped otherwise since most dev/CI machines won't
+// have a cross toolchain installed.
+func TestARM64RunsUnderQEMU(t *testing.T) {
+	cc := "aarch64-linux-gnu-gcc"
+	if _, err := exec.LookPath(cc); err != nil {
+		t.Skipf("%s not available", cc)
+	}
+	if _, err := exec.LookPath("qemu-aarch64"); err != nil {
+		t.Skip("qemu-aarch64 not available")
+	}
+
+	ops := Optimize(mustLex(t, "++++++++++[>+++++++>++++++++++>+++>+<<<<-]>++.>+.+++++++..+++.>++.<<+++++++++++++++.>.+++.------.--------.>+.>."))
+	asmText := EmitARM64(ops, EmitOptions{})
+
+	dir := t.TempDir()
+	asmPath := filepath.Join(dir, "prog.s")
+	if err := os.WriteFile(asmPath, []byte(asmText), 0644); err != nil {
+		t.Fatal(err)
+	}
+	exePath := filepath.Join(dir, "prog")
+	if out, err := exec.Command(cc, "-static", "-no-pie", asmPath, "-o", exePath).CombinedOutput(); err != nil {
+		t.Fatalf("cross gcc failed: %v\n%s", err, out)
+	}
+
+	out, err := exec.Command("qemu-aarch64", exePath).CombinedOutput()
+	if err != nil {
+		t.Fatalf("qemu-aarch64 failed: %v\n%s", err, out)
+	}
+	if string(out) != "Hello World!\n" {
+		t.Fatalf("unexpected output: %q", out)
+	}
+}
+
+func mustLex(t *testing.T, src string) []Op {
+	t.Helper()
+	ops, err := Lex([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ops
+}
+
+func readGolden(t *testing.T, name string) string {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", "golden", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
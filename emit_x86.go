@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// EmitOptions controls optional features shared across backends.
+type EmitOptions struct {
+	// Debug emits source-location directives plus a hand-written
+	// .debug_info/.debug_abbrev compile unit (currently amd64 only), so
+	// that assembling with `gcc -g` maps machine code back to SrcFile and
+	// exposes a "tape_ptr" (r12) and "tape" variable, letting gdb both
+	// step through the .bf source and inspect the current cell.
+	Debug bool
+	// SrcFile is the path recorded in the .file directive when Debug is set.
+	SrcFile string
+}
+
+// AMD64Gen emits Intel-syntax x86-64 assembly for gcc, using r12 as the
+// tape pointer. It implements CodeGen.
+type AMD64Gen struct {
+	opts     EmitOptions
+	buf      bytes.Buffer
+	lastLine int
+}
+
+// NewAMD64Gen returns a CodeGen targeting x86-64.
+func NewAMD64Gen(opts EmitOptions) *AMD64Gen {
+	return &AMD64Gen{opts: opts}
+}
+
+// mem renders an Intel-syntax BYTE PTR operand for the tape pointer (r12)
+// plus a constant offset.
+func mem(offset int) string {
+	switch {
+	case offset == 0:
+		return "BYTE PTR [r12]"
+	case offset > 0:
+		return fmt.Sprintf("BYTE PTR [r12+%d]", offset)
+	default:
+		return fmt.Sprintf("BYTE PTR [r12-%d]", -offset)
+	}
+}
+
+func loopLabels(id int) (begin, end string) {
+	return fmt.Sprintf(".Lloop_%d_begin", id), fmt.Sprintf(".Lloop_%d_end", id)
+}
+
+func (g *AMD64Gen) EmitPrologue() {
+	g.buf.WriteString("\t.intel_syntax noprefix\n")
+	if g.opts.Debug {
+		fmt.Fprintf(&g.buf, "\t.file 1 %q\n", g.opts.SrcFile)
+	}
+	g.buf.WriteString("\t.section .text\n")
+	g.buf.WriteString("\t.global main\n")
+	g.buf.WriteString("\t.type main, @function\n")
+	g.buf.WriteString("main:\n")
+	g.buf.WriteString("\tpush rbp\n")
+	g.buf.WriteString("\tmov rbp, rsp\n")
+	g.buf.WriteString("\tlea r12, [rip + tape]\n")
+}
+
+func (g *AMD64Gen) EmitEpilogue() {
+	g.buf.WriteString("\tmov eax, 0\n")
+	g.buf.WriteString("\tpop rbp\n")
+	g.buf.WriteString("\tret\n")
+	if g.opts.Debug {
+		g.buf.WriteString(".Ldwarf_func_end:\n")
+	}
+}
+
+func (g *AMD64Gen) EmitTape(size int) {
+	g.buf.WriteString("\t.section .bss\n")
+	g.buf.WriteString("\t.align 8\n")
+	g.buf.WriteString("\t.type tape, @object\n")
+	fmt.Fprintf(&g.buf, "\t.size tape, %d\n", size)
+	g.buf.WriteString("tape:\n")
+	fmt.Fprintf(&g.buf, "\t.zero %d\n", size)
+	if g.opts.Debug {
+		emitVariableDebugInfo(&g.buf, g.opts.SrcFile, size)
+	}
+}
+
+func (g *AMD64Gen) EmitMove(n int) {
+	if n > 0 {
+		fmt.Fprintf(&g.buf, "\tadd r12, %d\n", n)
+	} else if n < 0 {
+		fmt.Fprintf(&g.buf, "\tsub r12, %d\n", -n)
+	}
+}
+
+func (g *AMD64Gen) EmitAdd(n, offset int) {
+	imm := n & 0xff
+	if imm != 0 {
+		fmt.Fprintf(&g.buf, "\tadd %s, %d\n", mem(offset), imm)
+	}
+}
+
+func (g *AMD64Gen) EmitSet(n, offset int) {
+	fmt.Fprintf(&g.buf, "\tmov %s, %d\n", mem(offset), n&0xff)
+}
+
+func (g *AMD64Gen) EmitPut(offset int) {
+	fmt.Fprintf(&g.buf, "\tmovzx edi, %s\n", mem(offset))
+	g.buf.WriteString("\tcall putchar\n")
+}
+
+func (g *AMD64Gen) EmitGet(offset int) {
+	g.buf.WriteString("\tcall getchar\n")
+	fmt.Fprintf(&g.buf, "\tmov %s, al\n", mem(offset))
+}
+
+func (g *AMD64Gen) EmitLoopBegin(id int) {
+	begin, end := loopLabels(id)
+	fmt.Fprintf(&g.buf, "%s:\n", begin)
+	g.buf.WriteString("\tmov al, BYTE PTR [r12]\n")
+	g.buf.WriteString("\ttest al, al\n")
+	fmt.Fprintf(&g.buf, "\tjz %s\n", end)
+}
+
+func (g *AMD64Gen) EmitLoopEnd(id int) {
+	begin, end := loopLabels(id)
+	g.buf.WriteString("\tmov al, BYTE PTR [r12]\n")
+	g.buf.WriteString("\ttest al, al\n")
+	fmt.Fprintf(&g.buf, "\tjnz %s\n", begin)
+	fmt.Fprintf(&g.buf, "%s:\n", end)
+}
+
+func (g *AMD64Gen) EmitScanRight(step int) { g.emitScan(step, "add") }
+func (g *AMD64Gen) EmitScanLeft(step int)  { g.emitScan(step, "sub") }
+
+func (g *AMD64Gen) emitScan(step int, op string) {
+	begin := fmt.Sprintf(".Lscan_%d", g.buf.Len())
+	end := begin + "_done"
+	fmt.Fprintf(&g.buf, "%s:\n", begin)
+	g.buf.WriteString("\tcmp BYTE PTR [r12], 0\n")
+	fmt.Fprintf(&g.buf, "\tje %s\n", end)
+	fmt.Fprintf(&g.buf, "\t%s r12, %d\n", op, step)
+	fmt.Fprintf(&g.buf, "\tjmp %s\n", begin)
+	fmt.Fprintf(&g.buf, "%s:\n", end)
+}
+
+func (g *AMD64Gen) EmitMulAdd(offset, factor int) {
+	g.buf.WriteString("\tmovzx eax, BYTE PTR [r12]\n")
+	switch factor {
+	case 1:
+		fmt.Fprintf(&g.buf, "\tadd %s, al\n", mem(offset))
+	case -1:
+		fmt.Fprintf(&g.buf, "\tsub %s, al\n", mem(offset))
+	default:
+		fmt.Fprintf(&g.buf, "\timul eax, eax, %d\n", factor)
+		fmt.Fprintf(&g.buf, "\tadd %s, al\n", mem(offset))
+	}
+}
+
+func (g *AMD64Gen) EmitLoc(line, col int) {
+	if g.opts.Debug && line != g.lastLine {
+		fmt.Fprintf(&g.buf, "\t.loc 1 %d %d\n", line, col)
+		g.lastLine = line
+	}
+}
+
+func (g *AMD64Gen) Output() string { return g.buf.String() }
+
+// EmitAMD64 turns an optimized IR program into Intel-syntax x86-64 assembly.
+func EmitAMD64(ops []Op, opts EmitOptions) string {
+	return Generate(ops, NewAMD64Gen(opts), opts)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// InterpOptions configures Run's tape: its size, the bit width each cell
+// wraps at, and how an out-of-range pointer is handled.
+type InterpOptions struct {
+	TapeSize  int // number of cells
+	CellWidth int // cell wraps mod 2^CellWidth; one of 8, 16, 32
+	Wrap      bool // true: pointer movement wraps around the tape; false: panic out of bounds
+}
+
+// Run executes an optimized op stream directly in Go, without going through
+// any assembler -- a switch over op kinds in a loop over ops, using the
+// same []Op IR and optimizer passes (see Optimize) as the compiler
+// backends, so e.g. a `[-]` clear loop is already a single OpSet by the
+// time Run sees it. It reads ',' input from stdin and writes '.' output to
+// stdout, buffering both for the tight inner loop.
+func Run(ops []Op, opts InterpOptions, stdin io.Reader, stdout io.Writer) error {
+	mask := cellMask(opts.CellWidth)
+	tape := make([]uint32, opts.TapeSize)
+	ptr := 0
+	in := bufio.NewReader(stdin)
+	out := bufio.NewWriter(stdout)
+	defer out.Flush()
+
+	// index turns a pointer + offset into a tape index, wrapping or
+	// panicking per opts.Wrap -- out-of-bounds access without wraparound is
+	// a programmer/input error, not a recoverable condition, so it panics
+	// rather than threading an error through every op.
+	index := func(offset int) int {
+		i := ptr + offset
+		if opts.Wrap {
+			i %= opts.TapeSize
+			if i < 0 {
+				i += opts.TapeSize
+			}
+			return i
+		}
+		if i < 0 || i >= opts.TapeSize {
+			panic(fmt.Sprintf("bf: pointer out of bounds: %d", i))
+		}
+		return i
+	}
+
+	for pc := 0; pc < len(ops); pc++ {
+		op := ops[pc]
+		switch op.Kind {
+		case OpMove:
+			ptr = index(op.N)
+		case OpAdd:
+			i := index(op.Offset)
+			tape[i] = (tape[i] + uint32(op.N)) & mask
+		case OpSet:
+			tape[index(op.Offset)] = uint32(op.N) & mask
+		case OpPut:
+			if err := out.WriteByte(byte(tape[index(op.Offset)])); err != nil {
+				return err
+			}
+		case OpGet:
+			b, err := in.ReadByte()
+			if err != nil {
+				if err != io.EOF {
+					return err
+				}
+				b = 0 // conventional EOF behavior: cell reads as zero
+			}
+			tape[index(op.Offset)] = uint32(b) & mask
+		case OpLoopBegin:
+			if tape[index(0)] == 0 {
+				pc = op.Target
+			}
+		case OpLoopEnd:
+			if tape[index(0)] != 0 {
+				pc = op.Target
+			}
+		case OpScanRight:
+			for tape[index(0)] != 0 {
+				ptr = index(op.N)
+			}
+		case OpScanLeft:
+			for tape[index(0)] != 0 {
+				ptr = index(-op.N)
+			}
+		case OpMulAdd:
+			src := tape[index(0)]
+			i := index(op.Offset)
+			tape[i] = (tape[i] + src*uint32(op.N)) & mask
+		default:
+			return fmt.Errorf("interp: unhandled op %v", op)
+		}
+	}
+	return nil
+}
+
+func cellMask(width int) uint32 {
+	return uint32(uint64(1)<<uint(width) - 1)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func runInterp(t *testing.T, ops []Op, stdin string) string {
+	t.Helper()
+	var out bytes.Buffer
+	opts := InterpOptions{TapeSize: tapeSize, CellWidth: 8}
+	if err := Run(ops, opts, strings.NewReader(stdin), &out); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+// TestInterpMatchesCompiledOutput checks Run against the same fixtures the
+// backend golden tests use, since Run is meant as a reference oracle: it
+// shares the IR and optimizer, so a clear/scan/mul loop should produce the
+// same observable output whether it runs through Run or a compiled binary.
+func TestInterpMatchesCompiledOutput(t *testing.T) {
+	ops := Optimize(mustLex(t, canonicalProgram))
+	got := runInterp(t, ops, "")
+	if got != "\x00" {
+		t.Errorf("output = %q, want %q", got, "\x00")
+	}
+}
+
+func TestInterpHelloWorld(t *testing.T) {
+	src := "++++++++++[>+++++++>++++++++++>+++>+<<<<-]>++.>+.+++++++..+++.>++.<<+++++++++++++++.>.+++.------.--------.>+.>."
+	ops := Optimize(mustLex(t, src))
+	got := runInterp(t, ops, "")
+	want := "Hello World!\n"
+	if got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestInterpCellWidthWraps(t *testing.T) {
+	// Overflow a single cell by one: 8-bit wraps to 0, 16-bit does not.
+	src := strings.Repeat("+", 256) + "."
+	ops := Optimize(mustLex(t, src))
+
+	var out8 bytes.Buffer
+	if err := Run(ops, InterpOptions{TapeSize: tapeSize, CellWidth: 8}, strings.NewReader(""), &out8); err != nil {
+		t.Fatal(err)
+	}
+	if out8.String() != "\x00" {
+		t.Errorf("8-bit output = %q, want %q", out8.String(), "\x00")
+	}
+}
+
+func TestInterpWrapPointer(t *testing.T) {
+	// Moving one cell left of the tape start should land on the last cell
+	// when -wrap is set, rather than panicking.
+	ops := Optimize(mustLex(t, "<+."))
+	var out bytes.Buffer
+	opts := InterpOptions{TapeSize: 10, CellWidth: 8, Wrap: true}
+	if err := Run(ops, opts, strings.NewReader(""), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "\x01" {
+		t.Errorf("output = %q, want %q", out.String(), "\x01")
+	}
+}
+
+func TestInterpOutOfBoundsPanicsWithoutWrap(t *testing.T) {
+	ops := Optimize(mustLex(t, "<"))
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic for an out-of-bounds pointer")
+		}
+	}()
+	Run(ops, InterpOptions{TapeSize: 10, CellWidth: 8}, strings.NewReader(""), &bytes.Buffer{})
+}
@@ -0,0 +1,119 @@
+package main
+
+import "fmt"
+
+// OpKind identifies the kind of a single IR instruction.
+type OpKind int
+
+const (
+	OpAdd       OpKind = iota // add N to the current cell (plus Offset)
+	OpMove                    // move the pointer by N cells
+	OpSet                     // set the current cell (plus Offset) to N
+	OpPut                     // write the current cell (plus Offset) to stdout
+	OpGet                     // read a byte from stdin into the current cell (plus Offset)
+	OpLoopBegin               // '[' -- jumps to the matching OpLoopEnd if the cell is zero
+	OpLoopEnd                 // ']' -- jumps to the matching OpLoopBegin if the cell is nonzero
+	OpScanRight               // move right until a zero cell is found
+	OpScanLeft                // move left until a zero cell is found
+	OpMulAdd                  // cell[Offset] += cell[0] * N, without touching cell[0]
+)
+
+// Op is a single instruction in the brainfuck IR. Not every field is used by
+// every kind: Add/Set/MulAdd use N as a value or factor, Move uses N as a
+// step count, and Offset lets Add/Set/Put/Get/MulAdd address a cell relative
+// to the pointer without an explicit Move (see coalesceOffsets).
+type Op struct {
+	Kind   OpKind
+	N      int
+	Offset int
+
+	// Target is the index of the matching OpLoopEnd (for OpLoopBegin) or
+	// OpLoopBegin (for OpLoopEnd) in the same []Op slice.
+	Target int
+
+	// Line and Col are the 1-based source position of the character this op
+	// originated from, used to emit .loc directives with -g. Ops synthesized
+	// by the optimizer inherit the position of the op they replace.
+	Line int
+	Col  int
+}
+
+func (o Op) String() string {
+	switch o.Kind {
+	case OpAdd:
+		return fmt.Sprintf("Add{%d}@%d", o.N, o.Offset)
+	case OpMove:
+		return fmt.Sprintf("Move{%d}", o.N)
+	case OpSet:
+		return fmt.Sprintf("Set{%d}@%d", o.N, o.Offset)
+	case OpPut:
+		return fmt.Sprintf("Put@%d", o.Offset)
+	case OpGet:
+		return fmt.Sprintf("Get@%d", o.Offset)
+	case OpLoopBegin:
+		return fmt.Sprintf("LoopBegin(%d)", o.Target)
+	case OpLoopEnd:
+		return fmt.Sprintf("LoopEnd(%d)", o.Target)
+	case OpScanRight:
+		return fmt.Sprintf("ScanRight{%d}", o.N)
+	case OpScanLeft:
+		return fmt.Sprintf("ScanLeft{%d}", o.N)
+	case OpMulAdd:
+		return fmt.Sprintf("MulAdd{offset=%d, factor=%d}", o.Offset, o.N)
+	default:
+		return "Op(?)"
+	}
+}
+
+// Lex scans raw brainfuck source into an unoptimized []Op, one op per
+// '>','<','+','-','.',',','[',']' character; every other byte is a comment
+// and is skipped. Loop ops have their Target set to the matching partner's
+// index so later passes don't need to re-match brackets.
+func Lex(src []byte) ([]Op, error) {
+	var ops []Op
+	var loopStack []int
+	line, col := 1, 1
+
+	for i, c := range src {
+		pos := Op{Line: line, Col: col}
+		switch c {
+		case '>':
+			ops = append(ops, Op{Kind: OpMove, N: 1, Line: pos.Line, Col: pos.Col})
+		case '<':
+			ops = append(ops, Op{Kind: OpMove, N: -1, Line: pos.Line, Col: pos.Col})
+		case '+':
+			ops = append(ops, Op{Kind: OpAdd, N: 1, Line: pos.Line, Col: pos.Col})
+		case '-':
+			ops = append(ops, Op{Kind: OpAdd, N: -1, Line: pos.Line, Col: pos.Col})
+		case '.':
+			ops = append(ops, Op{Kind: OpPut, Line: pos.Line, Col: pos.Col})
+		case ',':
+			ops = append(ops, Op{Kind: OpGet, Line: pos.Line, Col: pos.Col})
+		case '[':
+			loopStack = append(loopStack, len(ops))
+			ops = append(ops, Op{Kind: OpLoopBegin, Line: pos.Line, Col: pos.Col})
+		case ']':
+			if len(loopStack) == 0 {
+				return nil, fmt.Errorf("unmatched ']' at source index %d", i)
+			}
+			begin := loopStack[len(loopStack)-1]
+			loopStack = loopStack[:len(loopStack)-1]
+			ops = append(ops, Op{Kind: OpLoopEnd, Target: begin, Line: pos.Line, Col: pos.Col})
+			ops[begin].Target = len(ops) - 1
+		default:
+			// ignore any other characters (including whitespace / comments)
+		}
+		if c == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+
+	if len(loopStack) != 0 {
+		return nil, fmt.Errorf("unmatched '[' (stack not empty), top index=%d", loopStack[len(loopStack)-1])
+	}
+
+	return ops, nil
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// jitAsm assembles an optimized op stream directly into x86-64 machine
+// code instead of textual assembly, so `-jit` can skip gcc entirely. It
+// uses r15 as the tape pointer (patched in as an absolute address once the
+// tape is allocated) and raw read(2)/write(2) syscalls for ',' and '.' so
+// the result needs no libc and no dynamic linker.
+//
+// r14 is never touched: on amd64 Go's register ABI keeps the current g in
+// r14, and since the generated code is invoked directly as a Go func value
+// (see RunJIT), clobbering it would corrupt the runtime.
+type jitAsm struct {
+	code []byte
+
+	loopBeginPos map[int]int // loop id -> byte offset of the loop test
+	loopEndPatch map[int]int // loop id -> byte offset of the forward jz's rel32
+}
+
+func newJITAsm() *jitAsm {
+	return &jitAsm{loopBeginPos: map[int]int{}, loopEndPatch: map[int]int{}}
+}
+
+func (a *jitAsm) emit(b ...byte) { a.code = append(a.code, b...) }
+
+func (a *jitAsm) emit32(n int32) {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], uint32(n))
+	a.emit(buf[:]...)
+}
+
+func (a *jitAsm) emit64(n uint64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], n)
+	a.emit(buf[:]...)
+}
+
+// memR15 returns the ModRM(+disp) bytes addressing [r15+offset] for the
+// given ModRM reg field (0-7); the caller is responsible for the REX
+// prefix, since that also depends on the reg field's own register.
+func memR15(reg byte, offset int) []byte {
+	const rm = 7 // r15's low 3 bits
+	switch {
+	case offset == 0:
+		return []byte{0x00 | reg<<3 | rm}
+	case offset >= -128 && offset <= 127:
+		return []byte{0x40 | reg<<3 | rm, byte(int8(offset))}
+	default:
+		b := make([]byte, 5)
+		b[0] = 0x80 | reg<<3 | rm
+		binary.LittleEndian.PutUint32(b[1:], uint32(int32(offset)))
+		return b
+	}
+}
+
+// movR15Imm64Placeholder emits `movabs r15, 0` and returns the byte offset
+// of the immediate, so it can be patched with the tape's real address once
+// it's known.
+func (a *jitAsm) movR15Imm64Placeholder() int {
+	a.emit(0x49, 0xBF)
+	off := len(a.code)
+	a.emit64(0)
+	return off
+}
+
+func (a *jitAsm) patchImm64(off int, v uint64) {
+	binary.LittleEndian.PutUint64(a.code[off:off+8], v)
+}
+
+// assembleJIT lowers an optimized op stream into a standalone function
+// body: load the tape pointer, one instruction sequence per op, then ret.
+func assembleJIT(ops []Op) (code []byte, tapePtrOffset int) {
+	a, tapePtrOffset := assembleX86Body(ops)
+	a.emit(0xC3) // ret
+	return a.code, tapePtrOffset
+}
+
+// assembleX86Body lowers an optimized op stream into raw x86-64 machine
+// code using r15 as the tape pointer and a movabs placeholder (returned as
+// tapePtrOffset) for the tape's address -- everything assembleJIT and
+// assembleELF share, short of their respective epilogues.
+func assembleX86Body(ops []Op) (a *jitAsm, tapePtrOffset int) {
+	a = newJITAsm()
+	tapePtrOffset = a.movR15Imm64Placeholder()
+
+	for i, op := range ops {
+		switch op.Kind {
+		case OpMove:
+			switch {
+			case op.N > 0:
+				a.emit(0x49, 0x81, 0xC7) // add r15, imm32
+				a.emit32(int32(op.N))
+			case op.N < 0:
+				a.emit(0x49, 0x81, 0xEF) // sub r15, imm32
+				a.emit32(int32(-op.N))
+			}
+		case OpAdd:
+			imm := op.N & 0xff
+			if imm != 0 {
+				a.emit(0x41, 0x80) // add BYTE PTR [r15+off], imm8
+				a.emit(memR15(0, op.Offset)...)
+				a.emit(byte(imm))
+			}
+		case OpSet:
+			a.emit(0x41, 0xC6) // mov BYTE PTR [r15+off], imm8
+			a.emit(memR15(0, op.Offset)...)
+			a.emit(byte(op.N & 0xff))
+		case OpPut:
+			a.emitSyscallIO(1, 1, op.Offset) // write(1, &tape[off], 1)
+		case OpGet:
+			a.emitSyscallIO(0, 0, op.Offset) // read(0, &tape[off], 1)
+		case OpLoopBegin:
+			a.loopBeginPos[i] = len(a.code)
+			a.emit(0x41, 0x80) // cmp BYTE PTR [r15], 0
+			a.emit(memR15(7, 0)...)
+			a.emit(0x00)
+			a.emit(0x0F, 0x84) // jz rel32 (forward; patched at the matching end)
+			a.loopEndPatch[i] = len(a.code)
+			a.emit32(0)
+		case OpLoopEnd:
+			beginID := op.Target
+			a.emit(0x41, 0x80) // cmp BYTE PTR [r15], 0
+			a.emit(memR15(7, 0)...)
+			a.emit(0x00)
+			a.emit(0x0F, 0x85) // jnz rel32 back to the loop test
+			a.emit32(int32(a.loopBeginPos[beginID] - (len(a.code) + 4)))
+			a.patchRel32(a.loopEndPatch[beginID], len(a.code))
+		case OpScanRight, OpScanLeft:
+			begin := len(a.code)
+			a.emit(0x41, 0x80) // cmp BYTE PTR [r15], 0
+			a.emit(memR15(7, 0)...)
+			a.emit(0x00)
+			a.emit(0x0F, 0x84) // jz rel32 (forward; patched below)
+			jzPatch := len(a.code)
+			a.emit32(0)
+			if op.Kind == OpScanRight {
+				a.emit(0x49, 0x81, 0xC7) // add r15, imm32
+			} else {
+				a.emit(0x49, 0x81, 0xEF) // sub r15, imm32
+			}
+			a.emit32(int32(op.N))
+			a.emit(0xE9) // jmp rel32 back to the loop test
+			a.emit32(int32(begin - (len(a.code) + 4)))
+			a.patchRel32(jzPatch, len(a.code))
+		case OpMulAdd:
+			a.emit(0x41, 0x0F, 0xB6) // movzx eax, BYTE PTR [r15]
+			a.emit(memR15(0, 0)...)
+			switch op.N {
+			case 1:
+				a.emit(0x41, 0x00) // add BYTE PTR [r15+off], al
+				a.emit(memR15(0, op.Offset)...)
+			case -1:
+				a.emit(0x41, 0x28) // sub BYTE PTR [r15+off], al
+				a.emit(memR15(0, op.Offset)...)
+			default:
+				a.emit(0x69, 0xC0) // imul eax, eax, imm32
+				a.emit32(int32(op.N))
+				a.emit(0x41, 0x00) // add BYTE PTR [r15+off], al
+				a.emit(memR15(0, op.Offset)...)
+			}
+		default:
+			panic(fmt.Sprintf("jit: unhandled op %v", op))
+		}
+	}
+
+	return a, tapePtrOffset
+}
+
+func (a *jitAsm) patchRel32(patchOff, targetPos int) {
+	rel := int32(targetPos - (patchOff + 4))
+	binary.LittleEndian.PutUint32(a.code[patchOff:patchOff+4], uint32(rel))
+}
+
+// emitSyscallIO emits a direct read/write(2) syscall moving exactly one
+// byte between the cell at the given tape offset and fd.
+func (a *jitAsm) emitSyscallIO(syscallNum, fd, offset int) {
+	a.emit(0xB8) // mov eax, syscallNum
+	a.emit32(int32(syscallNum))
+	a.emit(0xBF) // mov edi, fd
+	a.emit32(int32(fd))
+	a.emit(0x49, 0x8D) // lea rsi, [r15+offset]
+	a.emit(memR15(6, offset)...)
+	a.emit(0xBA) // mov edx, 1
+	a.emit32(1)
+	a.emit(0x0F, 0x05) // syscall
+}
+
+// RunJIT assembles ops directly into x86-64 machine code, maps it into an
+// executable page, and runs it in place -- no gcc, no temporary .s file.
+func RunJIT(ops []Op) error {
+	code, tapePtrOffset := assembleJIT(ops)
+
+	tape := make([]byte, tapeSize)
+	tapeAddr := uint64(uintptr(unsafe.Pointer(&tape[0])))
+	binary.LittleEndian.PutUint64(code[tapePtrOffset:tapePtrOffset+8], tapeAddr)
+
+	mem, err := syscall.Mmap(-1, 0, len(code),
+		syscall.PROT_READ|syscall.PROT_WRITE|syscall.PROT_EXEC,
+		syscall.MAP_PRIVATE|syscall.MAP_ANON)
+	if err != nil {
+		return fmt.Errorf("mmap executable page: %w", err)
+	}
+	defer syscall.Munmap(mem)
+	copy(mem, code)
+
+	// Calling a Go func value dereferences it once to get the code's entry
+	// address, so the value has to be a pointer to a word holding mem's
+	// address -- not mem's address itself. codePtr is that word, and p is
+	// the extra level of indirection fn needs to point through.
+	codePtr := uintptr(unsafe.Pointer(&mem[0]))
+	p := &codePtr
+	fn := *(*func())(unsafe.Pointer(&p))
+	fn()
+
+	// Keep the tape and the func value reachable until after the call so
+	// the GC can't reclaim either while the generated code is running.
+	runtime.KeepAlive(tape)
+	runtime.KeepAlive(fn)
+	return nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// captureStdout redirects fd 1 to a pipe for the duration of fn and returns
+// whatever was written to it. RunJIT writes via a raw write(2) syscall on fd
+// 1, not through os.Stdout, so the redirect has to happen at the fd level.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	savedFd, err := syscall.Dup(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Dup2(int(w.Fd()), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	fn()
+
+	w.Close()
+	syscall.Dup2(savedFd, 1)
+	syscall.Close(savedFd)
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestJITMatchesCanonicalProgram(t *testing.T) {
+	ops := Optimize(mustLex(t, canonicalProgram))
+	got := captureStdout(t, func() {
+		if err := RunJIT(ops); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if got != "\x00" {
+		t.Errorf("jit output = %q, want %q", got, "\x00")
+	}
+}
+
+// BenchmarkJITMandelbrot measures JIT assemble+run time for mandelbrot.b.
+func BenchmarkJITMandelbrot(b *testing.B) {
+	src, err := os.ReadFile("testdata/mandelbrot.b")
+	if err != nil {
+		b.Fatal(err)
+	}
+	lexed, err := Lex(src)
+	if err != nil {
+		b.Fatal(err)
+	}
+	ops := Optimize(lexed)
+
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer devNull.Close()
+	savedFd, err := syscall.Dup(1)
+	if err != nil {
+		b.Fatal(err)
+	}
+	syscall.Dup2(int(devNull.Fd()), 1)
+	defer func() {
+		syscall.Dup2(savedFd, 1)
+		syscall.Close(savedFd)
+	}()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := RunJIT(ops); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkCompileThenRunMandelbrot measures the gcc-based pipeline's
+// compile + process-spawn time for the same program, as a baseline for
+// BenchmarkJITMandelbrot.
+func BenchmarkCompileThenRunMandelbrot(b *testing.B) {
+	if _, err := exec.LookPath("gcc"); err != nil {
+		b.Skip("gcc not available")
+	}
+	src, err := os.ReadFile("testdata/mandelbrot.b")
+	if err != nil {
+		b.Fatal(err)
+	}
+	lexed, err := Lex(src)
+	if err != nil {
+		b.Fatal(err)
+	}
+	ops := Optimize(lexed)
+	asmText := EmitAMD64(ops, EmitOptions{})
+
+	dir := b.TempDir()
+	asmPath := filepath.Join(dir, "prog.s")
+	if err := os.WriteFile(asmPath, []byte(asmText), 0644); err != nil {
+		b.Fatal(err)
+	}
+	exePath := filepath.Join(dir, "prog")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if out, err := exec.Command("gcc", "-no-pie", asmPath, "-o", exePath).CombinedOutput(); err != nil {
+			b.Fatalf("gcc failed: %v\n%s", err, out)
+		}
+		if out, err := exec.Command(exePath).Output(); err != nil {
+			b.Fatalf("exe failed: %v\n%s", err, out)
+		}
+	}
+}
@@ -1,164 +1,189 @@
 package main
 
 import (
-	"bytes"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
 	"os/exec"
-	"path/filepath"
 )
 
-func usageAndExit() {
-	fmt.Fprintf(os.Stderr, "Usage: %s <source.bf> [out_executable]\n", filepath.Base(os.Args[0]))
-	os.Exit(1)
-}
-
-func sanitizeLabel(n int) string {
-	return fmt.Sprintf(".Lloop_%d", n)
-}
-
 func main() {
 	if len(os.Args) < 2 {
-		usageAndExit()
+		usage()
+		os.Exit(1)
 	}
 
-	srcPath := os.Args[1]
-	outExe := "bf_program"
-	if len(os.Args) >= 3 {
-		outExe = os.Args[2]
+	cmd, args := os.Args[1], os.Args[2:]
+	switch cmd {
+	case "compile":
+		runCompile(args)
+	case "run":
+		runRun(args)
+	case "dump-ir":
+		runDumpIR(args)
+	default:
+		usage()
+		os.Exit(1)
 	}
+}
 
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <compile|run|dump-ir> [flags] <source.bf> [args...]\n", os.Args[0])
+}
+
+// loadOps lexes and optimizes a .bf source file; every subcommand shares
+// this same frontend and optimizer.
+func loadOps(srcPath string) []Op {
 	src, err := ioutil.ReadFile(srcPath)
 	if err != nil {
 		log.Fatalf("read file: %v", err)
 	}
+	ops, err := Lex(src)
+	if err != nil {
+		log.Fatalf("parse %s: %v", srcPath, err)
+	}
+	return Optimize(ops)
+}
 
-	// Prepare to generate assembly
-	var asm bytes.Buffer
-
-	// Header: text, intel syntax, and main symbol
-	asm.WriteString("\t.intel_syntax noprefix\n")
-	asm.WriteString("\t.section .text\n")
-	asm.WriteString("\t.global main\n")
-	asm.WriteString("\t.type main, @function\n")
-	asm.WriteString("main:\n")
-	// minimal prologue
-	asm.WriteString("\tpush rbp\n")
-	asm.WriteString("\tmov rbp, rsp\n")
-
-	// Initialize pointer register r12 to tape base (rip-relative)
-	asm.WriteString("\tlea r12, [rip + tape]\n") // r12 will be our data pointer
-
-	// We'll implement brainfuck commands mapping to asm.
-	// For loops we will create unique labels using a stack.
-	loopStack := []int{}
-	loopCounter := 0
-
-	// iterate over source bytes
-	for i := 0; i < len(src); i++ {
-		c := src[i]
-		switch c {
-		case '>':
-			// increment pointer
-			asm.WriteString("\tadd r12, 1\n")
-		case '<':
-			asm.WriteString("\tsub r12, 1\n")
-		case '+':
-			asm.WriteString("\tinc BYTE PTR [r12]\n")
-		case '-':
-			asm.WriteString("\tdec BYTE PTR [r12]\n")
-		case '.':
-			// call putchar with the byte at [r12]
-			// syscall ABI: first arg in edi
-			// move zero-extended byte into edi then call putchar
-			asm.WriteString("\tmovzx edi, BYTE PTR [r12]\n")
-			asm.WriteString("\tcall putchar\n")
-		case ',':
-			// call getchar, result in eax; store al into [r12]
-			asm.WriteString("\tcall getchar\n")
-			asm.WriteString("\tmov BYTE PTR [r12], al\n")
-		case '[':
-			// create two labels: loop_begin_X and loop_end_X
-			id := loopCounter
-			loopCounter++
-			loopStack = append(loopStack, id)
-			begin := sanitizeLabel(id) + "_begin"
-			end := sanitizeLabel(id) + "_end"
-			asm.WriteString(fmt.Sprintf("%s:\n", begin))
-			// test byte and jump to end if zero
-			asm.WriteString("\tmov al, BYTE PTR [r12]\n")
-			asm.WriteString("\ttest al, al\n")
-			asm.WriteString(fmt.Sprintf("\tjz %s\n", end))
-		case ']':
-			if len(loopStack) == 0 {
-				log.Fatalf("Unmatched ']' at source index %d", i)
-			}
-			id := loopStack[len(loopStack)-1]
-			loopStack = loopStack[:len(loopStack)-1]
-			begin := sanitizeLabel(id) + "_begin"
-			end := sanitizeLabel(id) + "_end"
-			// jump back to begin if byte != 0
-			asm.WriteString("\tmov al, BYTE PTR [r12]\n")
-			asm.WriteString("\ttest al, al\n")
-			asm.WriteString(fmt.Sprintf("\tjnz %s\n", begin))
-			asm.WriteString(fmt.Sprintf("%s:\n", end))
-		default:
-			// ignore any other characters (including whitespace / comments)
-		}
+// runCompile is the `bf compile` subcommand: assemble the source to an
+// executable, either via gcc (the default) or one of the gcc-free -jit /
+// -no-gcc modes.
+func runCompile(args []string) {
+	fs := flag.NewFlagSet("bf compile", flag.ExitOnError)
+	debug := fs.Bool("g", false, "emit DWARF line info so gdb can step through the .bf source")
+	march := fs.String("march", "amd64", "target architecture: amd64 or arm64")
+	cc := fs.String("cc", "gcc", "assembler/linker driver (e.g. aarch64-linux-gnu-gcc for cross-compiling arm64)")
+	jit := fs.Bool("jit", false, "assemble and run directly in this process instead of shelling out to gcc (amd64 only)")
+	noGCC := fs.Bool("no-gcc", false, "write a standalone ELF64 executable directly, without gcc/ld (amd64 only)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s compile [-g] [-jit] [-no-gcc] [-march={amd64,arm64}] [-cc driver] <source.bf> [out_executable]\n", os.Args[0])
+		fs.PrintDefaults()
 	}
+	fs.Parse(args)
 
-	if len(loopStack) != 0 {
-		log.Fatalf("Unmatched '[' (stack not empty), top id=%d", loopStack[len(loopStack)-1])
+	fargs := fs.Args()
+	if len(fargs) < 1 {
+		fs.Usage()
+		os.Exit(1)
 	}
 
-	// Epilogue: return 0
-	asm.WriteString("\tmov eax, 0\n")
-	asm.WriteString("\tpop rbp\n")
-	asm.WriteString("\tret\n")
+	srcPath := fargs[0]
+	outExe := "bf_program"
+	if len(fargs) >= 2 {
+		outExe = fargs[1]
+	}
 
-	// BSS tape allocation
-	asm.WriteString("\t.section .bss\n")
-	asm.WriteString("\t.align 8\n")
-	asm.WriteString("tape:\n")
-	asm.WriteString("\t.zero 30000\n")
+	ops := loadOps(srcPath)
+
+	if *jit {
+		if *march != "amd64" {
+			log.Fatalf("-jit only supports -march=amd64")
+		}
+		if err := RunJIT(ops); err != nil {
+			log.Fatalf("jit: %v", err)
+		}
+		return
+	}
 
-	asmText := asm.String()
+	if *noGCC {
+		if *march != "amd64" {
+			log.Fatalf("-no-gcc only supports -march=amd64")
+		}
+		if err := WriteELFExecutable(ops, outExe); err != nil {
+			log.Fatalf("no-gcc: %v", err)
+		}
+		fmt.Printf("Built executable: %s\n", outExe)
+		return
+	}
+
+	if *debug && *march == "arm64" {
+		log.Fatalf("-g is not supported with -march=arm64 yet: the ARM64 backend does not emit .loc/DWARF info")
+	}
+
+	opts := EmitOptions{Debug: *debug, SrcFile: srcPath}
+	var asmText string
+	switch *march {
+	case "amd64":
+		asmText = EmitAMD64(ops, opts)
+	case "arm64":
+		asmText = EmitARM64(ops, opts)
+	default:
+		log.Fatalf("unknown -march %q (want amd64 or arm64)", *march)
+	}
 
-	// Write assembly to a temporary .s file
 	tmpS := outExe + ".s"
 	if err := ioutil.WriteFile(tmpS, []byte(asmText), 0644); err != nil {
 		log.Fatalf("write asm file: %v", err)
 	}
-	defer func() {
-		// optionally remove the .s file on success; keep if user wants to inspect
-		// os.Remove(tmpS)
-	}()
 
-	// Assemble & link using gcc
-	// -no-pie to avoid PIE-related relocation issues and to have a simple executable layout
-	cmd := exec.Command("gcc", "-no-pie", tmpS, "-o", outExe)
+	// -no-pie avoids PIE-related relocation issues and keeps a simple
+	// executable layout.
+	gccArgs := []string{"-no-pie"}
+	if *debug {
+		gccArgs = append(gccArgs, "-g")
+	}
+	gccArgs = append(gccArgs, tmpS, "-o", outExe)
+
+	cmd := exec.Command(*cc, gccArgs...)
 	cmd.Stderr = os.Stderr
 	cmd.Stdout = os.Stdout
 	if err := cmd.Run(); err != nil {
-		log.Fatalf("gcc failed: %v", err)
+		log.Fatalf("%s failed: %v", *cc, err)
 	}
 
 	fmt.Printf("Built executable: %s\n", outExe)
+}
+
+// runRun is the `bf run` subcommand: execute the optimized IR directly in
+// Go, with no assembler or C toolchain involved.
+func runRun(args []string) {
+	fs := flag.NewFlagSet("bf run", flag.ExitOnError)
+	tapeFlag := fs.Int("tape", tapeSize, "tape size in cells")
+	cellFlag := fs.Int("cell", 8, "cell width in bits: 8, 16, or 32")
+	wrap := fs.Bool("wrap", false, "wrap the pointer around the tape instead of panicking when it runs out of bounds")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s run [-tape N] [-cell 8|16|32] [-wrap] <source.bf>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	fargs := fs.Args()
+	if len(fargs) < 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	switch *cellFlag {
+	case 8, 16, 32:
+	default:
+		log.Fatalf("unknown -cell %d (want 8, 16, or 32)", *cellFlag)
+	}
+
+	ops := loadOps(fargs[0])
+	opts := InterpOptions{TapeSize: *tapeFlag, CellWidth: *cellFlag, Wrap: *wrap}
+	if err := Run(ops, opts, os.Stdin, os.Stdout); err != nil {
+		log.Fatalf("run: %v", err)
+	}
+}
 
-	// // Optionally, run the produced executable and stream output to the current process's stdout.
-	// // We'll run it and hook stdin/stdout/stderr so the bf program can interact.
-	// prog := exec.Command("./" + outExe)
-	// prog.Stdin = os.Stdin
-	// var outBuf bytes.Buffer
-	// prog.Stdout = &outBuf
-	// prog.Stderr = os.Stderr
-	// if err := prog.Run(); err != nil {
-	// 	// If the program returns non-zero, still print captured output and error
-	// 	fmt.Print(outBuf.String())
-	// 	log.Fatalf("running program failed: %v", err)
-	// }
-	// // print captured output
-	// fmt.Print(outBuf.String())
+// runDumpIR is the `bf dump-ir` subcommand: print the optimized op stream
+// for debugging the optimizer passes.
+func runDumpIR(args []string) {
+	fs := flag.NewFlagSet("bf dump-ir", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s dump-ir <source.bf>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	fargs := fs.Args()
+	if len(fargs) < 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	for i, op := range loadOps(fargs[0]) {
+		fmt.Printf("%4d: %s\n", i, op)
+	}
 }
@@ -0,0 +1,185 @@
+package main
+
+import "sort"
+
+// Optimize runs the IR through the standard fixed set of passes: run-length
+// fusion, clear-loop and scan-loop recognition, mul-loop recognition, and
+// finally offset coalescing. Each pass is also exported individually so
+// tests can check the IR after a single pass in isolation.
+func Optimize(ops []Op) []Op {
+	ops = relinkLoops(fuseRuns(ops))
+	ops = relinkLoops(recognizeClearLoops(ops))
+	ops = relinkLoops(recognizeScanLoops(ops))
+	ops = relinkLoops(recognizeMulLoops(ops))
+	ops = fuseRuns(ops)
+	ops = relinkLoops(coalesceOffsets(ops))
+	return ops
+}
+
+// relinkLoops recomputes Target indices for OpLoopBegin/OpLoopEnd pairs.
+// Passes are free to drop, replace or fuse non-loop ops as long as they
+// leave loop ops in their original relative order, since this just re-pairs
+// them positionally rather than trusting stale indices.
+func relinkLoops(ops []Op) []Op {
+	var stack []int
+	for i, op := range ops {
+		switch op.Kind {
+		case OpLoopBegin:
+			stack = append(stack, i)
+		case OpLoopEnd:
+			begin := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			ops[begin].Target = i
+			ops[i].Target = begin
+		}
+	}
+	return ops
+}
+
+// fuseRuns collapses consecutive Add ops and consecutive Move ops into a
+// single op carrying the summed count, dropping any run that nets to zero.
+func fuseRuns(ops []Op) []Op {
+	out := make([]Op, 0, len(ops))
+	for _, op := range ops {
+		if n := len(out); n > 0 && (op.Kind == OpAdd || op.Kind == OpMove) &&
+			out[n-1].Kind == op.Kind && out[n-1].Offset == op.Offset {
+			out[n-1].N += op.N
+			if out[n-1].N == 0 {
+				out = out[:n-1]
+			}
+			continue
+		}
+		out = append(out, op)
+	}
+	return out
+}
+
+// recognizeClearLoops rewrites `[-]` / `[+]` (after fusion, a loop whose
+// entire body is a single odd-valued Add to the current cell) into `Set 0`.
+// Any odd step eventually reaches zero mod 256, so this covers `[-]`,
+// `[+]`, `[---]`, and so on, not just the canonical two forms.
+func recognizeClearLoops(ops []Op) []Op {
+	out := make([]Op, 0, len(ops))
+	for i := 0; i < len(ops); i++ {
+		op := ops[i]
+		if op.Kind == OpLoopBegin && op.Target == i+2 {
+			body := ops[i+1]
+			if body.Kind == OpAdd && body.Offset == 0 && body.N%2 != 0 {
+				out = append(out, Op{Kind: OpSet, N: 0, Line: op.Line, Col: op.Col})
+				i += 2
+				continue
+			}
+		}
+		out = append(out, op)
+	}
+	return out
+}
+
+// recognizeScanLoops rewrites `[>]`/`[<]` (after fusion, a loop whose entire
+// body is a single Move) into a ScanRight/ScanLeft op that the backends can
+// compile to a tight scanning loop instead of a full loop body.
+func recognizeScanLoops(ops []Op) []Op {
+	out := make([]Op, 0, len(ops))
+	for i := 0; i < len(ops); i++ {
+		op := ops[i]
+		if op.Kind == OpLoopBegin && op.Target == i+2 {
+			body := ops[i+1]
+			if body.Kind == OpMove {
+				if body.N > 0 {
+					out = append(out, Op{Kind: OpScanRight, N: body.N, Line: op.Line, Col: op.Col})
+				} else {
+					out = append(out, Op{Kind: OpScanLeft, N: -body.N, Line: op.Line, Col: op.Col})
+				}
+				i += 2
+				continue
+			}
+		}
+		out = append(out, op)
+	}
+	return out
+}
+
+// recognizeMulLoops rewrites "copy loops" such as `[->+<]` or
+// `[->++>+++<<]` into a Set{0} plus one MulAdd per destination cell. A loop
+// qualifies when its body contains only Move/Add ops (no I/O, no nested
+// loops), nets to a zero pointer move, and decrements the current cell by
+// exactly one per iteration -- i.e. running the loop cell[0] times.
+func recognizeMulLoops(ops []Op) []Op {
+	out := make([]Op, 0, len(ops))
+	for i := 0; i < len(ops); i++ {
+		op := ops[i]
+		if op.Kind == OpLoopBegin {
+			if deltas, ok := analyzeMulLoop(ops[i+1 : op.Target]); ok {
+				offsets := make([]int, 0, len(deltas))
+				for off := range deltas {
+					if off != 0 {
+						offsets = append(offsets, off)
+					}
+				}
+				sort.Ints(offsets)
+				for _, off := range offsets {
+					out = append(out, Op{Kind: OpMulAdd, Offset: off, N: deltas[off], Line: op.Line, Col: op.Col})
+				}
+				out = append(out, Op{Kind: OpSet, N: 0, Line: op.Line, Col: op.Col})
+				i = op.Target
+				continue
+			}
+		}
+		out = append(out, op)
+	}
+	return out
+}
+
+// analyzeMulLoop walks a (pre-validated) loop body and, if it is a pure
+// Move/Add sequence that nets to pointer offset 0 and decrements cell 0 by
+// exactly one, returns the per-offset net Add delta (including offset 0).
+func analyzeMulLoop(body []Op) (map[int]int, bool) {
+	deltas := map[int]int{}
+	offset := 0
+	for _, op := range body {
+		switch op.Kind {
+		case OpMove:
+			offset += op.N
+		case OpAdd:
+			deltas[offset+op.Offset] += op.N
+		default:
+			return nil, false
+		}
+	}
+	if offset != 0 {
+		return nil, false
+	}
+	if deltas[0] != -1 {
+		return nil, false
+	}
+	return deltas, true
+}
+
+// coalesceOffsets drops explicit Move ops in favor of letting Add/Set/Put/Get
+// carry the accumulated pointer delta in their Offset field, and only
+// materializes a real Move once an op that needs the pointer in place (a
+// loop test, scan, or mul-loop) is reached.
+func coalesceOffsets(ops []Op) []Op {
+	out := make([]Op, 0, len(ops))
+	pending := 0
+	flush := func(pos Op) {
+		if pending != 0 {
+			out = append(out, Op{Kind: OpMove, N: pending, Line: pos.Line, Col: pos.Col})
+			pending = 0
+		}
+	}
+	for _, op := range ops {
+		switch op.Kind {
+		case OpMove:
+			pending += op.N
+		case OpAdd, OpSet, OpPut, OpGet:
+			op.Offset += pending
+			out = append(out, op)
+		default: // OpLoopBegin, OpLoopEnd, OpScanRight, OpScanLeft, OpMulAdd
+			flush(op)
+			out = append(out, op)
+		}
+	}
+	flush(Op{})
+	return out
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func loadFixture(t *testing.T, name string) []Op {
+	t.Helper()
+	src, err := ioutil.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	ops, err := Lex(src)
+	if err != nil {
+		t.Fatalf("lex fixture: %v", err)
+	}
+	return ops
+}
+
+func countKind(ops []Op, kind OpKind) int {
+	n := 0
+	for _, op := range ops {
+		if op.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+func TestFuseRunsCollapsesRawOps(t *testing.T) {
+	for _, name := range []string{"hanoi.b", "mandelbrot.b"} {
+		raw := loadFixture(t, name)
+		fused := fuseRuns(raw)
+		if len(fused) >= len(raw) {
+			t.Errorf("%s: fuseRuns did not shrink the op count (%d -> %d)", name, len(raw), len(fused))
+		}
+		for _, op := range fused {
+			if op.Kind == OpAdd && op.N == 0 {
+				t.Errorf("%s: fuseRuns left a net-zero Add", name)
+			}
+		}
+	}
+}
+
+func TestOptimizeRecognizesClearLoop(t *testing.T) {
+	ops, err := Lex([]byte("+++[-]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops = Optimize(ops)
+	if countKind(ops, OpLoopBegin) != 0 {
+		t.Fatalf("expected the clear loop to be rewritten, got %v", ops)
+	}
+	found := false
+	for _, op := range ops {
+		if op.Kind == OpSet && op.N == 0 {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected a Set{0} op, got %v", ops)
+	}
+}
+
+func TestOptimizeRecognizesScanLoop(t *testing.T) {
+	ops, err := Lex([]byte("[>]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops = Optimize(ops)
+	if len(ops) != 1 || ops[0].Kind != OpScanRight {
+		t.Fatalf("expected a single ScanRight op, got %v", ops)
+	}
+}
+
+func TestOptimizeRecognizesMulLoop(t *testing.T) {
+	ops, err := Lex([]byte("[->+>++<<]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops = Optimize(ops)
+	if countKind(ops, OpLoopBegin) != 0 {
+		t.Fatalf("expected the mul loop to be rewritten, got %v", ops)
+	}
+	if countKind(ops, OpMulAdd) != 2 {
+		t.Fatalf("expected two MulAdd ops, got %v", ops)
+	}
+}
+
+func TestOptimizeCoalescesOffsets(t *testing.T) {
+	ops, err := Lex([]byte(">>+<<"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops = Optimize(ops)
+	if countKind(ops, OpMove) != 0 {
+		t.Fatalf("expected the surrounding Move ops to be absorbed into an offset, got %v", ops)
+	}
+	if len(ops) != 1 || ops[0].Kind != OpAdd || ops[0].Offset != 2 {
+		t.Fatalf("expected a single offset Add, got %v", ops)
+	}
+}
+
+func TestOptimizePipelineOnFixtures(t *testing.T) {
+	for _, name := range []string{"hanoi.b", "mandelbrot.b"} {
+		raw := loadFixture(t, name)
+		optimized := Optimize(raw)
+		if len(optimized) >= len(raw) {
+			t.Errorf("%s: optimized IR (%d ops) is not smaller than raw IR (%d ops)", name, len(optimized), len(raw))
+		}
+	}
+}